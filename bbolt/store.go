@@ -0,0 +1,440 @@
+// bbolt provides a store.Store backed by the bbolt key/value database.
+// Store allows you to create and delete buckets in the root of the
+// database and allows you to read, write, and delete key/value pairs
+// within a bucket. Nested buckets are supported through the *Path variants
+// of these functions, which address a bucket by the chain of bucket names
+// leading to it. It also exposes a transactional API (View, Update) for
+// callers that need to compose several operations atomically, and a Watch
+// API for subscribing to changes made through Write, Delete, or Update,
+// beyond what the store.Store interface requires.
+package bbolt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/asggo/store"
+)
+
+// WalkFunc is called for each key/value pair when walking the database.
+type WalkFunc = store.WalkFunc
+
+// Store holds the bolt database
+type Store struct {
+	db *bolt.DB
+
+	// subsMu guards subs and nextSub. It is separate from bbolt's own
+	// locking so that fanning out events, which happens after a
+	// transaction has already committed, never contends with bbolt.
+	subsMu  sync.Mutex
+	subs    map[int]*subscriber
+	nextSub int
+}
+
+func init() {
+	store.Register("bbolt", func(dsn string) (store.Store, error) {
+		return NewStore(dsn)
+	})
+}
+
+// CreateBucket creates a new bucket with the given name at the root of the
+// database. An error is returned if the bucket cannot be created.
+func (s *Store) CreateBucket(bucket string) error {
+	return s.Update(func(tx *Tx) error {
+		_, err := tx.CreateBucket(bucket)
+		return err
+	})
+}
+
+// DeleteBucket deletes the bucket with the given name from the root of the
+// database. Returns an error if the bucket cannot be deleted.
+func (s *Store) DeleteBucket(bucket string) error {
+	return s.Update(func(tx *Tx) error {
+		return tx.DeleteBucket(bucket)
+	})
+}
+
+// Walk executes the WalkFunc on each bucket in the root.
+func (s *Store) Walk(fn WalkFunc) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			fn(string(k), v)
+		}
+
+		return nil
+	})
+}
+
+// WalkBucket executes the WalkBucketFunc on each key, value pair in the bucket.
+func (s *Store) WalkBucket(bucket string, fn WalkFunc) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		c := b.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			fn(string(k), v)
+		}
+
+		return nil
+	})
+}
+
+// WalkPrefix executes the WalkFunc on every key/value pair in a bucket where
+// the key matches the given prefix.
+func (s *Store) WalkPrefix(bucket, prefix string, fn WalkFunc) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		c := b.Cursor()
+		pre := []byte(prefix)
+
+		for k, v := c.Seek(pre); k != nil && bytes.HasPrefix(k, pre); k, v = c.Next() {
+			fn(string(k), v)
+		}
+
+		return nil
+	})
+}
+
+// walkPath walks the chain of nested buckets named by path, starting at
+// the root of the database, and returns the bucket at the end of the
+// chain. It returns an error naming the first bucket in the chain that
+// does not exist.
+func walkPath(tx *Tx, path []string) (*Bucket, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("store: empty bucket path")
+	}
+
+	b := tx.Bucket(path[0])
+	if b == nil {
+		return nil, fmt.Errorf("store: bucket %s does not exist", path[0])
+	}
+
+	for _, name := range path[1:] {
+		b = b.Bucket(name)
+		if b == nil {
+			return nil, fmt.Errorf("store: bucket %s does not exist", name)
+		}
+	}
+
+	return b, nil
+}
+
+// CreateBucketPath creates a chain of nested buckets, one for each name in
+// path, creating any bucket along the way that does not already exist. An
+// error is returned if any bucket in the chain cannot be created.
+func (s *Store) CreateBucketPath(path []string) error {
+	return s.Update(func(tx *Tx) error {
+		if len(path) == 0 {
+			return fmt.Errorf("store: empty bucket path")
+		}
+
+		b, err := tx.CreateBucket(path[0])
+		if err != nil {
+			return err
+		}
+
+		for _, name := range path[1:] {
+			b, err = b.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteBucketPath deletes the bucket at the end of path, leaving the
+// parent buckets in place. Returns an error if any bucket in path does not
+// exist or if the final bucket cannot be deleted.
+func (s *Store) DeleteBucketPath(path []string) error {
+	return s.Update(func(tx *Tx) error {
+		if len(path) == 0 {
+			return fmt.Errorf("store: empty bucket path")
+		}
+
+		if len(path) == 1 {
+			return tx.DeleteBucket(path[0])
+		}
+
+		parent, err := walkPath(tx, path[:len(path)-1])
+		if err != nil {
+			return err
+		}
+
+		return parent.DeleteBucket(path[len(path)-1])
+	})
+}
+
+// WalkBucketPath executes the WalkFunc on each key/value pair in the bucket
+// at the end of path. fn is called with a nil value for entries that are
+// themselves sub-buckets, so callers can tell sub-buckets apart from leaf
+// keys.
+func (s *Store) WalkBucketPath(path []string, fn WalkFunc) error {
+	return s.View(func(tx *Tx) error {
+		b, err := walkPath(tx, path)
+		if err != nil {
+			return err
+		}
+
+		c := b.Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			fn(string(k), v)
+		}
+
+		return nil
+	})
+}
+
+// WritePath stores the given key/value pair in the bucket at the end of
+// path. Returns an error if any bucket in path does not exist.
+func (s *Store) WritePath(path []string, key string, value []byte) error {
+	tx := &Tx{store: s}
+
+	err := s.db.Batch(func(btx *bolt.Tx) error {
+		tx.tx = btx
+		tx.pending = nil
+
+		b, err := walkPath(tx, path)
+		if err != nil {
+			return err
+		}
+
+		if err := b.Put(key, value); err != nil {
+			return fmt.Errorf("store: could not write to key %s in bucket %s: %s", key, strings.Join(path, "/"), err)
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		s.fanOut(tx.pending)
+	}
+
+	return err
+}
+
+// ReadPath gets the value associated with key in the bucket at the end of
+// path. Returns distinct errors depending on whether a bucket in path or
+// the key itself could not be found.
+func (s *Store) ReadPath(path []string, key string) ([]byte, error) {
+	var val []byte
+
+	err := s.View(func(tx *Tx) error {
+		b, err := walkPath(tx, path)
+		if err != nil {
+			return err
+		}
+
+		val = b.Get(key)
+		if val == nil {
+			return fmt.Errorf("store: key %s does not exist", key)
+		}
+
+		return nil
+	})
+
+	return val, err
+}
+
+// Read key/value pairs from a bucket in batches of count size. Update the
+// batch with the found items. On error, the key/value map will be nil and
+// should not be used.
+func (s *Store) ReadBatch(bucket, next string, count int) (map[string][]byte, string, error) {
+	var items map[string][]byte
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		items = make(map[string][]byte)
+		c := b.Cursor()
+
+		for k, v := c.Seek([]byte(next)); k != nil && len(items) < count; k, v = c.Next() {
+			items[string(k)] = v
+			next = string(k)
+		}
+
+		if len(items) != count {
+			next = ""
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, next, nil
+}
+
+// Write stores the given key/value pair in the given bucket.
+func (s *Store) Write(bucket, key string, value []byte) error {
+	tx := &Tx{store: s}
+
+	err := s.db.Batch(func(btx *bolt.Tx) error {
+		tx.tx = btx
+		tx.pending = nil
+
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		if err := b.Put(key, value); err != nil {
+			return fmt.Errorf("store: could not write to key %s in bucket %s: %s", key, bucket, err)
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		s.fanOut(tx.pending)
+	}
+
+	return err
+}
+
+// Read gets the value associated with the given key in the given bucket. If the
+// key does not exist, Read returns a nil value and an error.
+func (s *Store) Read(bucket, key string) ([]byte, error) {
+	var val []byte
+
+	err := s.View(func(tx *Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		val = b.Get(key)
+		if val == nil {
+			return fmt.Errorf("store: key %s does not exit", key)
+		}
+
+		return nil
+	})
+
+	return val, err
+}
+
+// Delete removes a key/value pair from the given bucket. An error is returned
+// if the key/value pair cannot be deleted.
+func (s *Store) Delete(bucket, key string) error {
+	return s.Update(func(tx *Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		if err := b.Delete(key); err != nil {
+			return fmt.Errorf("store: could not delete key %s in bucket %s", key, bucket)
+		}
+
+		return nil
+	})
+}
+
+// Backup the database to the given file.
+func (s *Store) Backup(filename string) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		file, err := os.Create(filename)
+		if err != nil {
+			return fmt.Errorf("store: could not create backup file %s: %s", filename, err)
+		}
+
+		defer file.Close()
+
+		_, err = tx.WriteTo(file)
+		if err != nil {
+			return fmt.Errorf("store: could not write to backup file %s: %s", filename, err)
+		}
+
+		return nil
+	})
+}
+
+// Compact copies every bucket and key/value pair into a freshly created
+// database at dstPath, reclaiming the free pages that accumulate in a
+// long-lived bbolt file after heavy delete/update workloads. Unlike
+// Backup, which copies the file as-is, the source's free pages are not
+// carried over into the new file.
+func (s *Store) Compact(dstPath string) error {
+	dst, err := bolt.Open(dstPath, 0640, nil)
+	if err != nil {
+		return fmt.Errorf("store: could not open destination database %s: %s", dstPath, err)
+	}
+
+	defer dst.Close()
+
+	if err := bolt.Compact(dst, s.db, 0); err != nil {
+		return fmt.Errorf("store: could not compact database into %s: %s", dstPath, err)
+	}
+
+	return nil
+}
+
+// Check walks the freelist and page tree looking for corruption and
+// returns every inconsistency it finds. A nil slice means the database is
+// consistent.
+func (s *Store) Check() []error {
+	var errs []error
+
+	s.db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+
+		return nil
+	})
+
+	return errs
+}
+
+// Close closes the connection to the bolt database.
+func (s *Store) Close() error {
+	err := s.db.Close()
+	if err != nil {
+		return fmt.Errorf("store: could not close database")
+	}
+
+	return nil
+}
+
+// Create a new store object with a bolt database located at filePath.
+func NewStore(filePath string) (*Store, error) {
+	var err error
+
+	s := new(Store)
+
+	for tries := 1; tries < 20; tries += 2 {
+		timeout := 1 << uint(tries) * time.Millisecond
+
+		db, err := bolt.Open(filePath, 0640, &bolt.Options{Timeout: timeout})
+		if err == nil {
+			s.db = db
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("store: can not open database %s: %s", filePath, err)
+}
@@ -0,0 +1,368 @@
+package bbolt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	testNewStore(t)
+	testBucket(t)
+	testKey(t)
+	testWalk(t)
+	testPath(t)
+	testTx(t)
+	testCompact(t)
+	testWatch(t)
+}
+
+func testNewStore(t *testing.T) {
+	// Open a database in a path that does not exist.
+	_, err := NewStore("bad/path/test.db")
+	if err == nil {
+		t.Fatal("Create Store: expected error got nil")
+	}
+
+	// Open a database
+	s, err := NewStore("test.db")
+	if err != nil {
+		t.Fatal("Create Store: unexpected error", err)
+	}
+	s.Close()
+	os.Remove("test.db")
+}
+
+func testBucket(t *testing.T) {
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	err := s.CreateBucket("bucket")
+	if err != nil {
+		t.Fatal("Create Bucket: unexpected error", err)
+	}
+
+	err = s.DeleteBucket("bucket")
+	if err != nil {
+		t.Fatal("Delete Bucket: unexpected error", err)
+	}
+
+	err = s.DeleteBucket("nonexistent")
+	if err == nil {
+		t.Fatal("Delete Bucket: expected an error while deleting bucket, got nil")
+	}
+}
+
+func testKey(t *testing.T) {
+	var data1 = []byte("Store this.")
+	var data2 = []byte("Store that.")
+
+	// Open a database
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	// Create a bucket for storing keys.
+	s.CreateBucket("bucket1")
+
+	// Create keys
+	err := s.Write("bucket1", "key1", data1)
+	if err != nil {
+		t.Fatal("Write Key: unexpected error", err)
+	}
+
+	s.Write("bucket1", "key2", data2)
+
+	// Read keys
+	val, err := s.Read("nonexistent", "key")
+	if err == nil {
+		t.Error("Read Key: expected error reading from nonexistent bucket")
+	}
+
+	val, err = s.Read("bucket1", "nonexistent")
+	if err == nil {
+		t.Error("Read Key: expected error when reading non-existent key")
+	}
+
+	val, _ = s.Read("bucket1", "key1")
+	if string(val) != string(data1) {
+		t.Error("Read: expected", string(data1), "got", string(val))
+	}
+
+	val, _ = s.Read("bucket1", "key2")
+	if string(val) != string(data2) {
+		t.Error("Read: expected", string(data2), "got", string(val))
+	}
+
+	// Update key2
+	s.Write("bucket1", "key2", data1)
+	val, _ = s.Read("bucket1", "key2")
+	if string(val) != string(data1) {
+		t.Error("Update: expected", string(data1), "got", string(val))
+	}
+}
+
+func testWalk(t *testing.T) {
+	// Open a database
+	store, _ := NewStore("test.db")
+	defer store.Close()
+	defer os.Remove("test.db")
+
+	store.CreateBucket("bucket")
+
+	for i := 0; i < 100; i++ {
+		store.Write("bucket", fmt.Sprintf("%d", i), nil)
+	}
+
+	var buckets []string
+	store.Walk(func(key string, val []byte) {
+		buckets = append(buckets, key)
+	})
+
+	if len(buckets) != 1 || buckets[0] != "bucket" {
+		t.Error("Walk: expected one bucket named bucket got", strings.Join(buckets, " "))
+	}
+
+	var keys []string
+	store.WalkBucket("bucket", func (key string, val []byte) {
+		keys = append(keys, key)
+	})
+
+	if len(keys) != 100 {
+		t.Error("WalkBucket: expected 100 keys got", len(keys))
+	}
+
+	var tens []string
+	store.WalkPrefix("bucket", "1", func(key string, val []byte) {
+		fmt.Println(key)
+		tens = append(tens, key)
+	})
+
+	if len(tens) != 11 {
+		t.Error("WalkPrefix: expected 11 keys got", len(tens))
+	}
+}
+
+func testPath(t *testing.T) {
+	var data = []byte("Store this.")
+
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	err := s.CreateBucketPath([]string{"users", "1", "sessions"})
+	if err != nil {
+		t.Fatal("Create Bucket Path: unexpected error", err)
+	}
+
+	err = s.WritePath([]string{"users", "1", "sessions"}, "token", data)
+	if err != nil {
+		t.Fatal("Write Path: unexpected error", err)
+	}
+
+	val, err := s.ReadPath([]string{"users", "1", "sessions"}, "token")
+	if err != nil {
+		t.Fatal("Read Path: unexpected error", err)
+	}
+
+	if string(val) != string(data) {
+		t.Error("Read Path: expected", string(data), "got", string(val))
+	}
+
+	_, err = s.ReadPath([]string{"users", "1", "nonexistent"}, "token")
+	if err == nil {
+		t.Error("Read Path: expected error reading from nonexistent bucket")
+	}
+
+	_, err = s.ReadPath([]string{"users", "1", "sessions"}, "nonexistent")
+	if err == nil {
+		t.Error("Read Path: expected error reading nonexistent key")
+	}
+
+	var entries []string
+	s.WalkBucketPath([]string{"users", "1"}, func(key string, val []byte) {
+		entries = append(entries, key)
+	})
+
+	if len(entries) != 1 || entries[0] != "sessions" {
+		t.Error("Walk Bucket Path: expected one entry named sessions got", strings.Join(entries, " "))
+	}
+
+	err = s.DeleteBucketPath([]string{"users", "1", "sessions"})
+	if err != nil {
+		t.Fatal("Delete Bucket Path: unexpected error", err)
+	}
+
+	err = s.DeleteBucketPath([]string{"users", "1", "nonexistent"})
+	if err == nil {
+		t.Error("Delete Bucket Path: expected error deleting nonexistent bucket")
+	}
+}
+
+func testTx(t *testing.T) {
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	s.CreateBucket("counters")
+
+	// A read-modify-write: generate a value only if none exists yet.
+	err := s.Update(func(tx *Tx) error {
+		b := tx.Bucket("counters")
+		if b == nil {
+			return fmt.Errorf("bucket counters does not exist")
+		}
+
+		if b.Get("count") == nil {
+			return b.Put("count", []byte("1"))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal("Update: unexpected error", err)
+	}
+
+	err = s.View(func(tx *Tx) error {
+		b := tx.Bucket("counters")
+		if b == nil {
+			return fmt.Errorf("bucket counters does not exist")
+		}
+
+		if string(b.Get("count")) != "1" {
+			t.Error("View: expected count 1 got", string(b.Get("count")))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal("View: unexpected error", err)
+	}
+
+	// Running the same read-modify-write again should not change the value.
+	s.Update(func(tx *Tx) error {
+		b := tx.Bucket("counters")
+
+		if b.Get("count") == nil {
+			return b.Put("count", []byte("2"))
+		}
+
+		return nil
+	})
+
+	val, _ := s.Read("counters", "count")
+	if string(val) != "1" {
+		t.Error("Update: expected count to remain 1 got", string(val))
+	}
+}
+
+func testCompact(t *testing.T) {
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	s.CreateBucket("bucket")
+
+	for i := 0; i < 10; i++ {
+		s.Write("bucket", fmt.Sprintf("%d", i), []byte("value"))
+	}
+
+	if errs := s.Check(); len(errs) != 0 {
+		t.Error("Check: expected no errors got", errs)
+	}
+
+	defer os.Remove("compacted.db")
+
+	err := s.Compact("compacted.db")
+	if err != nil {
+		t.Fatal("Compact: unexpected error", err)
+	}
+
+	dst, err := NewStore("compacted.db")
+	if err != nil {
+		t.Fatal("Compact: could not open compacted database", err)
+	}
+	defer dst.Close()
+
+	var keys []string
+	dst.WalkBucket("bucket", func(key string, val []byte) {
+		keys = append(keys, key)
+	})
+
+	if len(keys) != 10 {
+		t.Error("Compact: expected 10 keys got", len(keys))
+	}
+}
+
+func testWatch(t *testing.T) {
+	s, _ := NewStore("test.db")
+	defer s.Close()
+	defer os.Remove("test.db")
+
+	s.CreateBucket("sessions")
+
+	events, cancel, err := s.Watch("sessions", "user-")
+	if err != nil {
+		t.Fatal("Watch: unexpected error", err)
+	}
+	defer cancel()
+
+	s.Write("sessions", "user-1", []byte("token-1"))
+	s.Write("other", "ignored", []byte("x"))
+
+	select {
+	case ev := <-events:
+		if ev.Op != OpPut || ev.Key != "user-1" || string(ev.Value) != "token-1" {
+			t.Error("Watch: unexpected event", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch: timed out waiting for put event")
+	}
+
+	s.Write("sessions", "other-key", []byte("ignored"))
+
+	s.Delete("sessions", "user-1")
+
+	select {
+	case ev := <-events:
+		if ev.Op != OpDelete || ev.Key != "user-1" || string(ev.PrevValue) != "token-1" {
+			t.Error("Watch: unexpected event", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch: timed out waiting for delete event")
+	}
+
+	cancel()
+
+	err = s.Write("sessions", "user-2", []byte("token-2"))
+	if err != nil {
+		t.Fatal("Write: unexpected error", err)
+	}
+
+	select {
+	case ev, ok := <-events:
+		if ok {
+			t.Error("Watch: expected no more events after cancel, got", ev)
+		}
+	default:
+	}
+}
+
+func stringSliceEqual(s1, s2 []string) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+
+	return true
+}
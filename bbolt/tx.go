@@ -0,0 +1,170 @@
+package bbolt
+
+import (
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Tx wraps a bbolt transaction, letting callers compose multiple bucket
+// operations into a single atomic transaction instead of dropping down to
+// raw bbolt or relying on the top-level Store helpers, which each run in
+// their own transaction.
+type Tx struct {
+	store   *Store
+	tx      *bolt.Tx
+	pending []bucketEvent
+}
+
+// Bucket wraps a bbolt bucket reached through a Tx.
+type Bucket struct {
+	b    *bolt.Bucket
+	name string
+	tx   *Tx
+}
+
+// Bucket returns the named bucket at the root of the database, or nil if
+// it does not exist.
+func (t *Tx) Bucket(name string) *Bucket {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil
+	}
+
+	return &Bucket{b: b, name: name, tx: t}
+}
+
+// CreateBucket creates the named bucket at the root of the database if it
+// does not already exist.
+func (t *Tx) CreateBucket(name string) (*Bucket, error) {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("store: bucket %s not created: %s", name, err)
+	}
+
+	return &Bucket{b: b, name: name, tx: t}, nil
+}
+
+// DeleteBucket deletes the named bucket from the root of the database.
+func (t *Tx) DeleteBucket(name string) error {
+	if err := t.tx.DeleteBucket([]byte(name)); err != nil {
+		return fmt.Errorf("store: could not delete bucket %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// Bucket returns the named bucket nested within b, or nil if it does not
+// exist. Puts and deletes made through the returned bucket are still
+// reported to watchers of b's own root bucket name.
+func (b *Bucket) Bucket(name string) *Bucket {
+	nested := b.b.Bucket([]byte(name))
+	if nested == nil {
+		return nil
+	}
+
+	return &Bucket{b: nested, name: b.name, tx: b.tx}
+}
+
+// CreateBucket creates the named bucket nested within b if it does not
+// already exist.
+func (b *Bucket) CreateBucket(name string) (*Bucket, error) {
+	nested, err := b.b.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("store: bucket %s not created: %s", name, err)
+	}
+
+	return &Bucket{b: nested, name: b.name, tx: b.tx}, nil
+}
+
+// DeleteBucket deletes the named bucket nested within b.
+func (b *Bucket) DeleteBucket(name string) error {
+	if err := b.b.DeleteBucket([]byte(name)); err != nil {
+		return fmt.Errorf("store: could not delete bucket %s: %s", name, err)
+	}
+
+	return nil
+}
+
+// Put stores the given key/value pair in b.
+func (b *Bucket) Put(key string, value []byte) error {
+	var prev []byte
+	if b.tx != nil {
+		prev = b.b.Get([]byte(key))
+	}
+
+	if err := b.b.Put([]byte(key), value); err != nil {
+		return fmt.Errorf("store: could not write to key %s: %s", key, err)
+	}
+
+	b.queue(Event{Op: OpPut, Key: key, Value: value, PrevValue: prev})
+
+	return nil
+}
+
+// Get returns the value associated with key in b, or nil if it does not
+// exist.
+func (b *Bucket) Get(key string) []byte {
+	return b.b.Get([]byte(key))
+}
+
+// Delete removes a key/value pair from b.
+func (b *Bucket) Delete(key string) error {
+	var prev []byte
+	if b.tx != nil {
+		prev = b.b.Get([]byte(key))
+	}
+
+	if err := b.b.Delete([]byte(key)); err != nil {
+		return fmt.Errorf("store: could not delete key %s: %s", key, err)
+	}
+
+	b.queue(Event{Op: OpDelete, Key: key, PrevValue: prev})
+
+	return nil
+}
+
+// queue records ev against b's root bucket name so it can be fanned out to
+// watchers once the enclosing transaction commits.
+func (b *Bucket) queue(ev Event) {
+	if b.tx == nil || b.tx.store == nil {
+		return
+	}
+
+	b.tx.pending = append(b.tx.pending, bucketEvent{bucket: b.name, event: ev})
+}
+
+// Cursor returns a cursor for iterating over the key/value pairs in b.
+func (b *Bucket) Cursor() *bolt.Cursor {
+	return b.b.Cursor()
+}
+
+// View runs fn in a read-only transaction. Any error returned by fn is
+// returned by View.
+func (s *Store) View(fn func(tx *Tx) error) error {
+	return s.db.View(func(btx *bolt.Tx) error {
+		return fn(&Tx{store: s, tx: btx})
+	})
+}
+
+// Update runs fn in a read/write transaction. If fn returns an error, the
+// transaction is rolled back. Update lets callers batch multiple
+// operations, such as a read-modify-write, into a single atomic
+// transaction. Once the transaction commits, any puts or deletes made
+// through it are fanned out to matching Watch subscribers.
+func (s *Store) Update(fn func(tx *Tx) error) error {
+	tx := &Tx{store: s}
+
+	err := s.db.Update(func(btx *bolt.Tx) error {
+		tx.tx = btx
+		tx.pending = nil
+
+		return fn(tx)
+	})
+
+	if err == nil {
+		s.fanOut(tx.pending)
+	}
+
+	return err
+}
@@ -0,0 +1,141 @@
+package bbolt
+
+import "strings"
+
+// eventBuffer is the default capacity of a Watch subscriber's channel.
+const eventBuffer = 64
+
+// Op identifies the kind of change a watched Event reports.
+type Op int
+
+const (
+	// OpPut reports that a key was written.
+	OpPut Op = iota
+	// OpDelete reports that a key was removed.
+	OpDelete
+)
+
+// Event describes a single change to a watched key.
+type Event struct {
+	Op        Op
+	Key       string
+	Value     []byte
+	PrevValue []byte
+}
+
+// bucketEvent pairs an Event with the bucket it happened in, so it can be
+// matched against subscribers once a transaction has committed.
+type bucketEvent struct {
+	bucket string
+	event  Event
+}
+
+// subscriber is a single registered Watch call.
+type subscriber struct {
+	bucket string
+	prefix string
+	block  bool
+	ch     chan Event
+}
+
+// WatchOption configures a call to Watch.
+type WatchOption func(*subscriber)
+
+// WithBlocking makes the subscriber's channel block senders when full,
+// instead of dropping events. Use it only when the consumer reliably keeps
+// up, since a slow or stuck consumer will stall every Write/Delete/Update
+// that touches the watched bucket.
+func WithBlocking() WatchOption {
+	return func(s *subscriber) {
+		s.block = true
+	}
+}
+
+// Watch subscribes to changes to keys in bucket whose key matches prefix.
+// It returns a channel of events, a cancel function that unregisters the
+// subscriber and should be called once the caller is done watching, and an
+// error if the subscription could not be created. By default the returned
+// channel is buffered and drops events when full; use WithBlocking to
+// block writers instead.
+func (s *Store) Watch(bucket, prefix string, opts ...WatchOption) (<-chan Event, func(), error) {
+	sub := &subscriber{
+		bucket: bucket,
+		prefix: prefix,
+		ch:     make(chan Event, eventBuffer),
+	}
+
+	for _, opt := range opts {
+		opt(sub)
+	}
+
+	s.subsMu.Lock()
+
+	if s.subs == nil {
+		s.subs = make(map[int]*subscriber)
+	}
+
+	id := s.nextSub
+	s.nextSub++
+	s.subs[id] = sub
+
+	s.subsMu.Unlock()
+
+	cancel := func() {
+		s.subsMu.Lock()
+		delete(s.subs, id)
+		s.subsMu.Unlock()
+	}
+
+	return sub.ch, cancel, nil
+}
+
+// delivery pairs a matched subscriber with the event to send it, so fanOut
+// can send outside of subsMu.
+type delivery struct {
+	sub   *subscriber
+	event Event
+}
+
+// fanOut delivers each event to every subscriber whose bucket and prefix
+// match. It is called only after the transaction that produced events has
+// committed. Matching subscribers are collected under subsMu, then sent to
+// one after another after the lock is released, so a blocked WithBlocking
+// subscriber never holds the lock other goroutines need for Watch, cancel,
+// or the next fanOut; it does hold up the deliveries after it in this call.
+func (s *Store) fanOut(events []bucketEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	var deliveries []delivery
+
+	s.subsMu.Lock()
+
+	for _, be := range events {
+		for _, sub := range s.subs {
+			if sub.bucket != be.bucket {
+				continue
+			}
+
+			if sub.prefix != "" && !strings.HasPrefix(be.event.Key, sub.prefix) {
+				continue
+			}
+
+			deliveries = append(deliveries, delivery{sub: sub, event: be.event})
+		}
+	}
+
+	s.subsMu.Unlock()
+
+	for _, d := range deliveries {
+		if d.sub.block {
+			d.sub.ch <- d.event
+			continue
+		}
+
+		select {
+		case d.sub.ch <- d.event:
+		default:
+		}
+	}
+}
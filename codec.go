@@ -0,0 +1,74 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec marshals and unmarshals values to and from the byte slices that a
+// Store operates on, so callers don't have to open-code
+// json.Marshal/Unmarshal (or similar) around every Write/Read.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values as JSON.
+type JSONCodec struct{}
+
+// Marshal encodes v as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON data into v.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec encodes values with encoding/gob.
+type GobCodec struct{}
+
+// Marshal encodes v as gob.
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes gob data into v.
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// PutObject marshals v with c and writes it to key in bucket.
+func PutObject[T any](s Store, bucket, key string, v T, c Codec) error {
+	data, err := c.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("store: could not encode value for key %s in bucket %s: %s", key, bucket, err)
+	}
+
+	return s.Write(bucket, key, data)
+}
+
+// GetObject reads the value at key in bucket and unmarshals it with c.
+func GetObject[T any](s Store, bucket, key string, c Codec) (T, error) {
+	var v T
+
+	data, err := s.Read(bucket, key)
+	if err != nil {
+		return v, err
+	}
+
+	if err := c.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("store: could not decode value for key %s in bucket %s: %s", key, bucket, err)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,55 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/asggo/store"
+	"github.com/asggo/store/memory"
+)
+
+type person struct {
+	Name string
+	Age  int
+}
+
+func TestCodec(t *testing.T) {
+	s := memory.NewStore()
+	defer s.Close()
+
+	s.CreateBucket("people")
+
+	want := person{Name: "Ada", Age: 36}
+
+	err := store.PutObject(s, "people", "ada", want, store.JSONCodec{})
+	if err != nil {
+		t.Fatal("PutObject: unexpected error", err)
+	}
+
+	got, err := store.GetObject[person](s, "people", "ada", store.JSONCodec{})
+	if err != nil {
+		t.Fatal("GetObject: unexpected error", err)
+	}
+
+	if got != want {
+		t.Error("GetObject:", "expected", want, "got", got)
+	}
+
+	err = store.PutObject(s, "people", "ada-gob", want, store.GobCodec{})
+	if err != nil {
+		t.Fatal("PutObject: unexpected error", err)
+	}
+
+	got, err = store.GetObject[person](s, "people", "ada-gob", store.GobCodec{})
+	if err != nil {
+		t.Fatal("GetObject: unexpected error", err)
+	}
+
+	if got != want {
+		t.Error("GetObject:", "expected", want, "got", got)
+	}
+
+	_, err = store.GetObject[person](s, "people", "nonexistent", store.JSONCodec{})
+	if err == nil {
+		t.Error("GetObject: expected error reading nonexistent key")
+	}
+}
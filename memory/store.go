@@ -0,0 +1,265 @@
+// memory provides a store.Store backed by an in-process map. It is useful
+// for tests and command-line tools that want store.Store semantics without
+// persisting anything to disk.
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/asggo/store"
+)
+
+// Store holds buckets and their key/value pairs in memory. Store is safe
+// for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+func init() {
+	store.Register("memory", func(dsn string) (store.Store, error) {
+		return NewStore(), nil
+	})
+}
+
+// NewStore creates a new, empty in-memory store.
+func NewStore() *Store {
+	return &Store{buckets: make(map[string]map[string][]byte)}
+}
+
+// CreateBucket creates a new bucket with the given name at the root of
+// the store.
+func (s *Store) CreateBucket(bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+
+	return nil
+}
+
+// DeleteBucket deletes the bucket with the given name from the root of
+// the store. Returns an error if the bucket does not exist.
+func (s *Store) DeleteBucket(bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket]; !ok {
+		return fmt.Errorf("store: could not delete bucket %s: bucket does not exist", bucket)
+	}
+
+	delete(s.buckets, bucket)
+
+	return nil
+}
+
+// Read gets the value associated with the given key in the given bucket.
+func (s *Store) Read(bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	val, ok := b[key]
+	if !ok {
+		return nil, fmt.Errorf("store: key %s does not exist", key)
+	}
+
+	return val, nil
+}
+
+// Write stores the given key/value pair in the given bucket.
+func (s *Store) Write(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	b[key] = value
+
+	return nil
+}
+
+// Delete removes a key/value pair from the given bucket.
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	if _, ok := b[key]; !ok {
+		return fmt.Errorf("store: could not delete key %s in bucket %s", key, bucket)
+	}
+
+	delete(b, key)
+
+	return nil
+}
+
+// Walk executes fn on each bucket at the root of the store.
+func (s *Store) Walk(fn store.WalkFunc) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, bucket := range s.sortedBuckets() {
+		fn(bucket, nil)
+	}
+
+	return nil
+}
+
+// WalkBucket executes fn on each key/value pair in the bucket.
+func (s *Store) WalkBucket(bucket string, fn store.WalkFunc) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	for _, key := range sortedKeys(b) {
+		fn(key, b[key])
+	}
+
+	return nil
+}
+
+// WalkPrefix executes fn on every key/value pair in a bucket where the key
+// matches the given prefix.
+func (s *Store) WalkPrefix(bucket, prefix string, fn store.WalkFunc) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	for _, key := range sortedKeys(b) {
+		if strings.HasPrefix(key, prefix) {
+			fn(key, b[key])
+		}
+	}
+
+	return nil
+}
+
+// ReadBatch reads key/value pairs from a bucket in batches of count size.
+// Update the batch with the found items. On error, the key/value map will
+// be nil and should not be used.
+func (s *Store) ReadBatch(bucket, next string, count int) (map[string][]byte, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, "", fmt.Errorf("store: bucket %s does not exist", bucket)
+	}
+
+	items := make(map[string][]byte)
+
+	for _, key := range sortedKeys(b) {
+		if key < next {
+			continue
+		}
+
+		items[key] = b[key]
+		next = key
+
+		if len(items) == count {
+			break
+		}
+	}
+
+	if len(items) != count {
+		next = ""
+	}
+
+	return items, next, nil
+}
+
+// CreateBucketPath is not supported by the memory backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) CreateBucketPath(path []string) error {
+	return fmt.Errorf("store: nested buckets are not supported by the memory backend")
+}
+
+// DeleteBucketPath is not supported by the memory backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) DeleteBucketPath(path []string) error {
+	return fmt.Errorf("store: nested buckets are not supported by the memory backend")
+}
+
+// WritePath is not supported by the memory backend; buckets are a single
+// flat namespace with no nesting.
+func (s *Store) WritePath(path []string, key string, value []byte) error {
+	return fmt.Errorf("store: nested buckets are not supported by the memory backend")
+}
+
+// ReadPath is not supported by the memory backend; buckets are a single
+// flat namespace with no nesting.
+func (s *Store) ReadPath(path []string, key string) ([]byte, error) {
+	return nil, fmt.Errorf("store: nested buckets are not supported by the memory backend")
+}
+
+// WalkBucketPath is not supported by the memory backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) WalkBucketPath(path []string, fn store.WalkFunc) error {
+	return fmt.Errorf("store: nested buckets are not supported by the memory backend")
+}
+
+// Backup is not supported by the memory backend; it always returns an
+// error and writes nothing to filename.
+func (s *Store) Backup(filename string) error {
+	return fmt.Errorf("store: backup is not supported by the memory backend")
+}
+
+// Close discards the store's contents. After Close, the Store must not be
+// used.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buckets = nil
+
+	return nil
+}
+
+func (s *Store) sortedBuckets() []string {
+	names := make([]string, 0, len(s.buckets))
+
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func sortedKeys(b map[string][]byte) []string {
+	keys := make([]string, 0, len(b))
+
+	for key := range b {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
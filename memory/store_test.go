@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+
+	if err := s.CreateBucket("bucket"); err != nil {
+		t.Fatal("Create Bucket: unexpected error", err)
+	}
+
+	if err := s.Write("bucket", "zkey", []byte("value")); err != nil {
+		t.Fatal("Write: unexpected error", err)
+	}
+
+	val, err := s.Read("bucket", "zkey")
+	if err != nil {
+		t.Fatal("Read: unexpected error", err)
+	}
+
+	if string(val) != "value" {
+		t.Error("Read: expected value got", string(val))
+	}
+
+	if _, err := s.Read("bucket", "nonexistent"); err == nil {
+		t.Error("Read: expected error reading nonexistent key")
+	}
+
+	if _, err := s.Read("nonexistent", "zkey"); err == nil {
+		t.Error("Read: expected error reading from nonexistent bucket")
+	}
+
+	for i := 0; i < 5; i++ {
+		s.Write("bucket", fmt.Sprintf("k%d", i), nil)
+	}
+
+	var keys []string
+	s.WalkPrefix("bucket", "k", func(key string, val []byte) {
+		keys = append(keys, key)
+	})
+
+	if len(keys) != 5 {
+		t.Error("WalkPrefix: expected 5 keys got", len(keys))
+	}
+
+	if err := s.Delete("bucket", "zkey"); err != nil {
+		t.Fatal("Delete: unexpected error", err)
+	}
+
+	if err := s.DeleteBucket("bucket"); err != nil {
+		t.Fatal("Delete Bucket: unexpected error", err)
+	}
+
+	if err := s.DeleteBucket("nonexistent"); err == nil {
+		t.Error("Delete Bucket: expected error deleting nonexistent bucket")
+	}
+}
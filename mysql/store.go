@@ -0,0 +1,300 @@
+// mysql provides a store.Store backed by a MySQL database. Buckets and
+// their keys are modeled as rows in a single kv table keyed on
+// (bucket, key), with a companion buckets table tracking bucket existence
+// independently of whether it currently holds any keys.
+package mysql
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/asggo/store"
+)
+
+// likeEscaper escapes the LIKE wildcards % and _, and the escape character
+// itself, so a caller-supplied prefix is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const bucketsTable = `
+CREATE TABLE IF NOT EXISTS buckets (
+	bucket VARCHAR(255) PRIMARY KEY
+)`
+
+const kvTable = `
+CREATE TABLE IF NOT EXISTS kv (
+	bucket VARCHAR(255) NOT NULL,
+	` + "`key`" + ` VARCHAR(255) NOT NULL,
+	value BLOB NOT NULL,
+	PRIMARY KEY (bucket, ` + "`key`" + `),
+	FOREIGN KEY (bucket) REFERENCES buckets (bucket) ON DELETE CASCADE
+)`
+
+// Store holds the connection to a MySQL database.
+type Store struct {
+	db *sql.DB
+}
+
+func init() {
+	store.Register("mysql", func(dsn string) (store.Store, error) {
+		return NewStore(dsn)
+	})
+}
+
+// NewStore opens a connection to the MySQL database named by dsn and
+// creates the tables backing the key/value store if they do not already
+// exist.
+func NewStore(dsn string) (*Store, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("store: could not open database: %s", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("store: could not connect to database: %s", err)
+	}
+
+	s := &Store{db: db}
+
+	if _, err := s.db.Exec(bucketsTable); err != nil {
+		return nil, fmt.Errorf("store: could not create buckets table: %s", err)
+	}
+
+	if _, err := s.db.Exec(kvTable); err != nil {
+		return nil, fmt.Errorf("store: could not create kv table: %s", err)
+	}
+
+	return s, nil
+}
+
+// CreateBucket creates a new bucket with the given name. An error is
+// returned if the bucket cannot be created.
+func (s *Store) CreateBucket(bucket string) error {
+	_, err := s.db.Exec(`INSERT IGNORE INTO buckets (bucket) VALUES (?)`, bucket)
+	if err != nil {
+		return fmt.Errorf("store: bucket %s not created: %s", bucket, err)
+	}
+
+	return nil
+}
+
+// DeleteBucket deletes the bucket with the given name and all of its
+// key/value pairs. Returns an error if the bucket does not exist.
+func (s *Store) DeleteBucket(bucket string) error {
+	res, err := s.db.Exec(`DELETE FROM buckets WHERE bucket = ?`, bucket)
+	if err != nil {
+		return fmt.Errorf("store: could not delete bucket %s: %s", bucket, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("store: could not delete bucket %s", bucket)
+	}
+
+	return nil
+}
+
+// Read gets the value associated with the given key in the given bucket.
+func (s *Store) Read(bucket, key string) ([]byte, error) {
+	var val []byte
+
+	row := s.db.QueryRow(`SELECT value FROM kv WHERE bucket = ? AND `+"`key`"+` = ?`, bucket, key)
+	if err := row.Scan(&val); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("store: key %s does not exist", key)
+		}
+
+		return nil, fmt.Errorf("store: could not read key %s in bucket %s: %s", key, bucket, err)
+	}
+
+	return val, nil
+}
+
+// Write stores the given key/value pair in the given bucket.
+func (s *Store) Write(bucket, key string, value []byte) error {
+	_, err := s.db.Exec(`
+		INSERT INTO kv (bucket, `+"`key`"+`, value) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE value = VALUES(value)`, bucket, key, value)
+	if err != nil {
+		return fmt.Errorf("store: could not write to key %s in bucket %s: %s", key, bucket, err)
+	}
+
+	return nil
+}
+
+// Delete removes a key/value pair from the given bucket.
+func (s *Store) Delete(bucket, key string) error {
+	res, err := s.db.Exec(`DELETE FROM kv WHERE bucket = ? AND `+"`key`"+` = ?`, bucket, key)
+	if err != nil {
+		return fmt.Errorf("store: could not delete key %s in bucket %s: %s", key, bucket, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("store: could not delete key %s in bucket %s", key, bucket)
+	}
+
+	return nil
+}
+
+// Walk executes fn on each bucket.
+func (s *Store) Walk(fn store.WalkFunc) error {
+	rows, err := s.db.Query(`SELECT bucket FROM buckets ORDER BY bucket`)
+	if err != nil {
+		return fmt.Errorf("store: could not walk buckets: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket string
+
+		if err := rows.Scan(&bucket); err != nil {
+			return fmt.Errorf("store: could not walk buckets: %s", err)
+		}
+
+		fn(bucket, nil)
+	}
+
+	return rows.Err()
+}
+
+// WalkBucket executes fn on each key/value pair in the bucket.
+func (s *Store) WalkBucket(bucket string, fn store.WalkFunc) error {
+	if err := s.requireBucket(bucket); err != nil {
+		return err
+	}
+
+	return s.walk(fn, `SELECT `+"`key`"+`, value FROM kv WHERE bucket = ? ORDER BY `+"`key`", bucket)
+}
+
+// WalkPrefix executes fn on every key/value pair in a bucket where the key
+// matches the given prefix.
+func (s *Store) WalkPrefix(bucket, prefix string, fn store.WalkFunc) error {
+	if err := s.requireBucket(bucket); err != nil {
+		return err
+	}
+
+	return s.walk(fn, `SELECT `+"`key`"+`, value FROM kv WHERE bucket = ? AND `+"`key`"+` LIKE CONCAT(?, '%') ESCAPE '\\' ORDER BY `+"`key`", bucket, likeEscaper.Replace(prefix))
+}
+
+// requireBucket returns an error if bucket has not been created.
+func (s *Store) requireBucket(bucket string) error {
+	var exists int
+
+	row := s.db.QueryRow(`SELECT 1 FROM buckets WHERE bucket = ?`, bucket)
+	if err := row.Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("store: bucket %s does not exist", bucket)
+		}
+
+		return fmt.Errorf("store: could not check bucket %s: %s", bucket, err)
+	}
+
+	return nil
+}
+
+func (s *Store) walk(fn store.WalkFunc, query string, args ...interface{}) error {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("store: could not walk bucket: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var val []byte
+
+		if err := rows.Scan(&key, &val); err != nil {
+			return fmt.Errorf("store: could not walk bucket: %s", err)
+		}
+
+		fn(key, val)
+	}
+
+	return rows.Err()
+}
+
+// ReadBatch reads key/value pairs from a bucket in batches of count size.
+// Update the batch with the found items. On error, the key/value map will
+// be nil and should not be used.
+func (s *Store) ReadBatch(bucket, next string, count int) (map[string][]byte, string, error) {
+	if err := s.requireBucket(bucket); err != nil {
+		return nil, "", err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+"`key`"+`, value FROM kv WHERE bucket = ? AND `+"`key`"+` >= ? ORDER BY `+"`key`"+` LIMIT ?`,
+		bucket, next, count)
+	if err != nil {
+		return nil, "", fmt.Errorf("store: could not read batch from bucket %s: %s", bucket, err)
+	}
+
+	defer rows.Close()
+
+	items := make(map[string][]byte)
+
+	for rows.Next() {
+		var key string
+		var val []byte
+
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, "", fmt.Errorf("store: could not read batch from bucket %s: %s", bucket, err)
+		}
+
+		items[key] = val
+		next = key
+	}
+
+	if len(items) != count {
+		next = ""
+	}
+
+	return items, next, nil
+}
+
+// CreateBucketPath is not supported by the mysql backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) CreateBucketPath(path []string) error {
+	return fmt.Errorf("store: nested buckets are not supported by the mysql backend")
+}
+
+// DeleteBucketPath is not supported by the mysql backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) DeleteBucketPath(path []string) error {
+	return fmt.Errorf("store: nested buckets are not supported by the mysql backend")
+}
+
+// WritePath is not supported by the mysql backend; buckets are a single
+// flat namespace with no nesting.
+func (s *Store) WritePath(path []string, key string, value []byte) error {
+	return fmt.Errorf("store: nested buckets are not supported by the mysql backend")
+}
+
+// ReadPath is not supported by the mysql backend; buckets are a single
+// flat namespace with no nesting.
+func (s *Store) ReadPath(path []string, key string) ([]byte, error) {
+	return nil, fmt.Errorf("store: nested buckets are not supported by the mysql backend")
+}
+
+// WalkBucketPath is not supported by the mysql backend; buckets are a
+// single flat namespace with no nesting.
+func (s *Store) WalkBucketPath(path []string, fn store.WalkFunc) error {
+	return fmt.Errorf("store: nested buckets are not supported by the mysql backend")
+}
+
+// Backup is not supported by the mysql backend; use mysqldump or binlog
+// replication against the underlying database instead.
+func (s *Store) Backup(filename string) error {
+	return fmt.Errorf("store: backup is not supported by the mysql backend")
+}
+
+// Close closes the connection to the database.
+func (s *Store) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("store: could not close database")
+	}
+
+	return nil
+}
@@ -1,10 +1,17 @@
 package main
 
+// kv.go predates store.Store and still calls backend methods (AllBuckets,
+// AllKeys, FindBuckets, FindKeys, AllVals, FindVals, a single-return Read)
+// that have never existed on it; it also redeclares get, find, and main
+// alongside kvweb.go, which is package main in the same directory. Neither
+// of these was introduced or fixed by the store.Store migration.
+
 import (
 	"fmt"
 	"os"
 
 	"github.com/asggo/store"
+	"github.com/asggo/store/bbolt"
 )
 
 func help() {
@@ -26,6 +33,8 @@ Actions:
 	find <bucketname> <string>       Find keys in the bucket, which contain
 	                                 the string.
 	backup <filename>                Backup the database to this file.
+	compact <filename>               Compact the database into this file.
+	check                            Check the database for corruption.
 	`
 	fmt.Println(u)
 	os.Exit(1)
@@ -33,7 +42,7 @@ Actions:
 
 // add <bucketname>                 Adds a new bucket to the database.
 // add <bucketname> <key> <value>   Add the key/value to the bucket.
-func add(db *store.Store, args []string) {
+func add(db store.Store, args []string) {
 	switch len(args) {
 	case 1:
 		err := db.CreateBucket(args[0])
@@ -53,7 +62,7 @@ func add(db *store.Store, args []string) {
 // get                      Returns a list of buckets.
 // get <bucketname>         Returns all keys in a bucket.
 // get <bucketname> <key>   Returns the value of the key in the bucket.
-func get(db *store.Store, args []string) {
+func get(db store.Store, args []string) {
 	var items []string
 	var err error
 
@@ -82,7 +91,7 @@ func get(db *store.Store, args []string) {
 
 // delete <bucketname>         Delete the bucket and its keys.
 // delete <bucketname> <key>   Delete the key/value in the bucket
-func delete(db *store.Store, args []string) {
+func delete(db store.Store, args []string) {
 	switch len(args) {
 	case 1:
 		err := db.DeleteBucket(args[0])
@@ -101,7 +110,7 @@ func delete(db *store.Store, args []string) {
 
 // find <string>                Find all buckets in the database, which contain the string.
 // find <bucketname> <string>   Find all keys in the bucket, which contain the string.
-func find(db *store.Store, args []string) {
+func find(db store.Store, args []string) {
 	var items []string
 	var err error
 
@@ -128,7 +137,7 @@ func find(db *store.Store, args []string) {
 
 // val <bucketname>            Return all values in the bucket.
 // val <bucketname> <string>   Return all values in the bucket, which contain the string.
-func val(db *store.Store, args []string) {
+func val(db store.Store, args []string) {
 	var items []string
 	var err error
 
@@ -153,7 +162,7 @@ func val(db *store.Store, args []string) {
 	}
 }
 
-func backup(db *store.Store, args []string) {
+func backup(db store.Store, args []string) {
 	switch len(args) {
 	case 1:
 		err := db.Backup(args[0])
@@ -166,6 +175,36 @@ func backup(db *store.Store, args []string) {
 	}
 }
 
+// compact <filename>   Compact the database into this file.
+func compact(db *bbolt.Store, args []string) {
+	switch len(args) {
+	case 1:
+		err := db.Compact(args[0])
+		if err != nil {
+			fmt.Printf("Could not compact database to %s: %s\n", args[0], err)
+		}
+	default:
+		help()
+	}
+}
+
+// check   Check the database for corruption.
+func check(db *bbolt.Store, args []string) {
+	if len(args) != 0 {
+		help()
+	}
+
+	errs := db.Check()
+	if len(errs) == 0 {
+		fmt.Println("OK")
+		return
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+}
+
 func main() {
 	if len(os.Args) < 3 {
 		help()
@@ -173,7 +212,7 @@ func main() {
 
 	// Open our database file.
 	dbfile := os.Args[1]
-	db, err := store.NewStore(dbfile)
+	db, err := bbolt.NewStore(dbfile)
 	if err != nil {
 		fmt.Println("Could not open database file:", err)
 	}
@@ -193,6 +232,10 @@ func main() {
 		find(db, os.Args[3:])
 	case "backup":
 		backup(db, os.Args[3:])
+	case "compact":
+		compact(db, os.Args[3:])
+	case "check":
+		check(db, os.Args[3:])
 	default:
 		help()
 	}
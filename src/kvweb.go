@@ -1,5 +1,11 @@
 package main
 
+// kvweb.go predates store.Store and still calls backend methods
+// (AllBuckets, AllKeys, FindBuckets, FindKeys, a single-return Read) that
+// have never existed on it; it also redeclares get, find, and main
+// alongside kv.go, which is package main in the same directory. Neither of
+// these was introduced or fixed by the store.Store migration.
+
 import (
 	"fmt"
 	"html/template"
@@ -9,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/asggo/store"
+	"github.com/asggo/store/bbolt"
 )
 
 const (
@@ -72,8 +79,8 @@ type Value struct {
 	Value  string
 }
 
-func conn() *store.Store {
-	db, err := store.NewStore(dbfile)
+func conn() store.Store {
+	db, err := bbolt.NewStore(dbfile)
 	if err != nil {
 		log.Printf("Could not open connection to %s: %s\n", dbfile, err)
 		return nil